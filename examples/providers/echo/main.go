@@ -0,0 +1,94 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Command echo is a minimal example of a controllers.Provider: it reports
+// every Pod as Running as soon as kwok starts managing it, skipping the
+// template machinery entirely. It is a starting point for Go-native
+// providers, e.g. one that replays a recorded trace or talks to an
+// external model over gRPC.
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/clientcmd"
+
+	"sigs.k8s.io/kwok/pkg/kwok/controllers"
+	"sigs.k8s.io/kwok/pkg/log"
+	"sigs.k8s.io/kwok/pkg/utils/envs"
+)
+
+// echoProvider reports every Pod it is asked about as Running.
+type echoProvider struct{}
+
+func (echoProvider) CreatePod(ctx context.Context, pod *corev1.Pod) error { return nil }
+func (echoProvider) UpdatePod(ctx context.Context, pod *corev1.Pod) error { return nil }
+func (echoProvider) DeletePod(ctx context.Context, pod *corev1.Pod) error { return nil }
+
+func (echoProvider) GetPodStatus(ctx context.Context, namespace, name string) (*corev1.PodStatus, error) {
+	return &corev1.PodStatus{
+		Phase:     corev1.PodRunning,
+		StartTime: &metav1.Time{Time: time.Now()},
+	}, nil
+}
+
+func (echoProvider) NodeConditions(ctx context.Context) []corev1.NodeCondition {
+	return []corev1.NodeCondition{
+		{Type: corev1.NodeReady, Status: corev1.ConditionTrue, Reason: "KubeletReady"},
+	}
+}
+
+func main() {
+	ctx := context.Background()
+	ctx = log.NewContext(ctx, log.NewLogger(os.Stderr, log.InfoLevel))
+
+	kubeconfig := envs.GetEnv("KUBECONFIG", "")
+	cfg, err := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(
+		&clientcmd.ClientConfigLoadingRules{ExplicitPath: kubeconfig},
+		&clientcmd.ConfigOverrides{}).ClientConfig()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	clientset, err := kubernetes.NewForConfig(cfg)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	ctr, err := controllers.NewController(controllers.Config{
+		ClientSet:      clientset,
+		ManageAllNodes: true,
+		Provider:       echoProvider{},
+	})
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	if err := ctr.Start(ctx); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	<-ctx.Done()
+}
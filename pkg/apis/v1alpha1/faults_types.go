@@ -0,0 +1,117 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// NodeFault injects a condition onto the Nodes matching Selector, so
+// operators can be tested against real failure modes (DiskPressure,
+// MemoryPressure, NotReady) without needing actual broken kubelets.
+type NodeFault struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec FaultSpec `json:"spec"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// NodeFaultList is a list of NodeFault.
+type NodeFaultList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+
+	Items []NodeFault `json:"items"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// PodFault injects a status condition, a container waiting state, or an
+// eviction onto the Pods matching Selector.
+type PodFault struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec FaultSpec `json:"spec"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// PodFaultList is a list of PodFault.
+type PodFaultList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+
+	Items []PodFault `json:"items"`
+}
+
+// FaultSpec describes a single fault to inject, when to start injecting it,
+// how long to hold it, and whether it recurs.
+type FaultSpec struct {
+	// Selector matches the Nodes or Pods this fault applies to. Only
+	// MatchLabels and MatchAnnotations are used; MatchPodPhase and CEL are
+	// ignored.
+	Selector *StageSelector `json:"selector,omitempty"`
+
+	// NodeCondition sets a Node condition, e.g. DiskPressure=True.
+	NodeCondition *FaultNodeCondition `json:"nodeCondition,omitempty"`
+	// PodCondition sets a Pod status condition.
+	PodCondition *FaultPodCondition `json:"podCondition,omitempty"`
+	// ContainerWaiting puts a container into a waiting state with a reason
+	// such as ImagePullBackOff, CrashLoopBackOff or ErrImagePull.
+	ContainerWaiting *FaultContainerWaiting `json:"containerWaiting,omitempty"`
+	// Evict, when true, evicts the matching Pods instead of patching their status.
+	Evict bool `json:"evict,omitempty"`
+
+	// StartAfter delays the fault's first application relative to when it
+	// was loaded or the CR was created.
+	StartAfter metav1.Duration `json:"startAfter,omitempty"`
+	// Duration is how long the fault is held before being cleared. Zero means indefinite.
+	Duration metav1.Duration `json:"duration,omitempty"`
+	// Recurrence is the interval at which the fault is re-applied after
+	// Duration clears it. Zero means the fault runs once.
+	Recurrence metav1.Duration `json:"recurrence,omitempty"`
+}
+
+// FaultNodeCondition is the Node condition a NodeFault injects.
+type FaultNodeCondition struct {
+	Type    string `json:"type"`
+	Status  string `json:"status"`
+	Reason  string `json:"reason,omitempty"`
+	Message string `json:"message,omitempty"`
+}
+
+// FaultPodCondition is the Pod status condition a PodFault injects.
+type FaultPodCondition struct {
+	Type    string `json:"type"`
+	Status  string `json:"status"`
+	Reason  string `json:"reason,omitempty"`
+	Message string `json:"message,omitempty"`
+}
+
+// FaultContainerWaiting is the container waiting state a PodFault injects.
+type FaultContainerWaiting struct {
+	// ContainerName restricts the fault to a single container. Empty applies
+	// it to every container in the Pod.
+	ContainerName string `json:"containerName,omitempty"`
+	Reason        string `json:"reason"`
+	Message       string `json:"message,omitempty"`
+}
@@ -0,0 +1,138 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// Stage describes a single step of a simulated Pod lifecycle: when it
+// applies, how long to wait before applying it, and what it changes on the
+// Pod.
+type Stage struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec StageSpec `json:"spec"`
+}
+
+// StageSpec holds the configuration for a Stage.
+type StageSpec struct {
+	// Selector matches the Pods this stage applies to by label, annotation or
+	// phase. A Stage whose selector sets CEL is never selected.
+	Selector *StageSelector `json:"selector,omitempty"`
+
+	// Delay is how long to wait before applying Next, once Selector matches.
+	Delay *StageDelay `json:"delay,omitempty"`
+
+	// Next is the status change applied once Delay elapses: a literal
+	// StatusPatch, or a StatusTemplate rendered with the same function map as
+	// the legacy PodStatusTemplate and decoded over a copy of the Pod's
+	// current status, so any list it sets (e.g. conditions) replaces the
+	// current one.
+	Next StageNext `json:"next"`
+
+	// Weight controls probabilistic branching between consecutive Stages that
+	// share a Selector within the same StageSet. Stages are chosen with probability
+	// proportional to their Weight among the matching candidates. Defaults to 1.
+	Weight int32 `json:"weight,omitempty"`
+}
+
+// StageSelector matches Pods eligible for a Stage.
+type StageSelector struct {
+	// MatchLabels is a label selector on the Pod.
+	MatchLabels map[string]string `json:"matchLabels,omitempty"`
+	// MatchAnnotations is an annotation selector on the Pod.
+	MatchAnnotations map[string]string `json:"matchAnnotations,omitempty"`
+	// MatchPodPhase matches Pods whose current status.Phase is one of the
+	// listed phases, empty status.Phase matching "". This is how an ordered
+	// StageSet expresses a lifecycle like Pending -> ContainerCreating ->
+	// Running: each Stage selects on the phase the previous one produced.
+	MatchPodPhase []string `json:"matchPodPhase,omitempty"`
+	// CEL is a Common Expression Language predicate over the Pod. It is not
+	// evaluated: a Stage whose selector sets it never matches, and fault
+	// selection ignores it.
+	CEL string `json:"cel,omitempty"`
+}
+
+// StageDelay is a duration or a jittered duration range.
+type StageDelay struct {
+	// Duration is a fixed delay, e.g. "10s".
+	Duration metav1.Duration `json:"duration,omitempty"`
+	// DurationFrom and DurationTo describe a delay sampled uniformly from
+	// the range [DurationFrom, DurationTo]. Both must be set together and
+	// take precedence over Duration.
+	DurationFrom metav1.Duration `json:"durationFrom,omitempty"`
+	DurationTo   metav1.Duration `json:"durationTo,omitempty"`
+}
+
+// StageNext is the change a Stage applies once its delay elapses.
+type StageNext struct {
+	// StatusTemplate is a Go template rendering a Pod status patch, in the
+	// same dialect as the legacy PodStatusTemplate.
+	StatusTemplate string `json:"statusTemplate,omitempty"`
+	// StatusPatch is a literal partial Pod status JSON patch, used instead
+	// of StatusTemplate when no templating is needed.
+	StatusPatch *StatusPatch `json:"statusPatch,omitempty"`
+}
+
+// StatusPatch is a partial, literal Pod status used by a Stage's Next.
+type StatusPatch struct {
+	Phase   string `json:"phase,omitempty"`
+	Reason  string `json:"reason,omitempty"`
+	Message string `json:"message,omitempty"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// StageList is a list of Stage.
+type StageList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+
+	Items []Stage `json:"items"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// StageSet groups named Stages into a reusable, named Pod lifecycle, e.g.
+// "normal", "image-pull-backoff", "crash-loop" or "oom".
+type StageSet struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec StageSetSpec `json:"spec"`
+}
+
+// StageSetSpec holds the configuration for a StageSet.
+type StageSetSpec struct {
+	// Stages are evaluated in order; the first whose Selector matches the
+	// Pod's current state is applied.
+	Stages []StageSpec `json:"stages"`
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// StageSetList is a list of StageSet.
+type StageSetList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+
+	Items []StageSet `json:"items"`
+}
@@ -0,0 +1,472 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package faults turns kwok from a happy-path simulator into a chaos
+// harness: it schedules node and pod fault injections (conditions, waiting
+// containers, evictions) so operators can be tested against real failure
+// modes without needing actual broken kubelets. It is modeled on the Node
+// Problem Detector's rule-based fault reporting.
+package faults
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	policyv1 "k8s.io/api/policy/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/yaml"
+	"k8s.io/client-go/kubernetes"
+
+	"sigs.k8s.io/kwok/pkg/apis/v1alpha1"
+	"sigs.k8s.io/kwok/pkg/log"
+)
+
+// NodeFaultLister lists the NodeFault objects currently registered in the cluster.
+type NodeFaultLister interface {
+	List() []*v1alpha1.NodeFault
+}
+
+// PodFaultLister lists the PodFault objects currently registered in the cluster.
+type PodFaultLister interface {
+	List() []*v1alpha1.PodFault
+}
+
+// Config holds the configuration for a Controller.
+type Config struct {
+	ClientSet kubernetes.Interface
+
+	// NodeRules and PodRules are loaded once from the --faults-config file.
+	NodeRules []v1alpha1.NodeFault
+	PodRules  []v1alpha1.PodFault
+
+	// NodeFaultLister and PodFaultLister, when set, add the NodeFault/PodFault
+	// CRs they list to the rules. They are listed once, when Start runs; CRs
+	// created after that are not picked up.
+	NodeFaultLister NodeFaultLister
+	PodFaultLister  PodFaultLister
+}
+
+// Controller schedules and applies the configured node and pod faults.
+type Controller struct {
+	conf Config
+	wg   sync.WaitGroup
+}
+
+// NewController returns a new fault-injection Controller.
+func NewController(conf Config) (*Controller, error) {
+	if conf.ClientSet == nil {
+		return nil, fmt.Errorf("clientset is required")
+	}
+	return &Controller{conf: conf}, nil
+}
+
+// Start schedules every configured and live-registered rule in its own
+// goroutine. It returns once all rules are scheduled; it does not block
+// until they finish (use Wait for that).
+func (c *Controller) Start(ctx context.Context) error {
+	for i := range c.conf.NodeRules {
+		c.runNodeRuleAsync(ctx, &c.conf.NodeRules[i])
+	}
+	for i := range c.conf.PodRules {
+		c.runPodRuleAsync(ctx, &c.conf.PodRules[i])
+	}
+	if c.conf.NodeFaultLister != nil {
+		for _, rule := range c.conf.NodeFaultLister.List() {
+			c.runNodeRuleAsync(ctx, rule)
+		}
+	}
+	if c.conf.PodFaultLister != nil {
+		for _, rule := range c.conf.PodFaultLister.List() {
+			c.runPodRuleAsync(ctx, rule)
+		}
+	}
+	return nil
+}
+
+// Wait blocks until every scheduled rule's goroutine has returned.
+func (c *Controller) Wait() {
+	c.wg.Wait()
+}
+
+func (c *Controller) runNodeRuleAsync(ctx context.Context, rule *v1alpha1.NodeFault) {
+	c.wg.Add(1)
+	go func() {
+		defer c.wg.Done()
+		logger := log.FromContext(ctx).With("nodeFault", rule.Name)
+		// prior remembers, per node, the NodeCondition that was in place
+		// before the fault was first applied, so clearing it can restore
+		// that condition instead of guessing an inverted status.
+		prior := map[string]*corev1.NodeCondition{}
+		schedule(ctx, rule.Spec, func(apply bool) {
+			if err := c.applyNodeFault(ctx, rule, apply, prior); err != nil {
+				logger.Error("Failed to apply node fault", err)
+			}
+		})
+	}()
+}
+
+func (c *Controller) runPodRuleAsync(ctx context.Context, rule *v1alpha1.PodFault) {
+	c.wg.Add(1)
+	go func() {
+		defer c.wg.Done()
+		logger := log.FromContext(ctx).With("podFault", rule.Name)
+		// prior remembers, per pod, the PodCondition that was in place
+		// before the fault was first applied, so clearing it can restore
+		// that condition instead of guessing an inverted status.
+		prior := map[string]*corev1.PodCondition{}
+		schedule(ctx, rule.Spec, func(apply bool) {
+			if err := c.applyPodFault(ctx, rule, apply, prior); err != nil {
+				logger.Error("Failed to apply pod fault", err)
+			}
+		})
+	}()
+}
+
+// schedule waits StartAfter, then repeatedly applies and clears the fault:
+// apply, wait Duration and clear (only if Duration is set), wait Recurrence,
+// repeat. A zero Recurrence means the fault is applied once, and cleared once
+// Duration elapses; a zero Duration means the loop never clears it.
+func schedule(ctx context.Context, spec v1alpha1.FaultSpec, set func(apply bool)) {
+	if !sleep(ctx, spec.StartAfter.Duration) {
+		return
+	}
+	for {
+		set(true)
+
+		if spec.Duration.Duration > 0 {
+			if !sleep(ctx, spec.Duration.Duration) {
+				return
+			}
+			set(false)
+		}
+
+		if spec.Recurrence.Duration <= 0 {
+			return
+		}
+		if !sleep(ctx, spec.Recurrence.Duration) {
+			return
+		}
+	}
+}
+
+// sleep waits for d or until ctx is done, returning false in the latter case.
+func sleep(ctx context.Context, d time.Duration) bool {
+	if d <= 0 {
+		return true
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return false
+	case <-timer.C:
+		return true
+	}
+}
+
+// applyNodeFault applies fault.Spec.NodeCondition to every matching node, or
+// clears it. prior is keyed by node name and carries, across the apply/clear
+// pair, the NodeCondition that was in place before the fault was first
+// applied, so clearing restores it (or removes the condition entirely if
+// there was none) instead of blindly inverting the injected status.
+func (c *Controller) applyNodeFault(ctx context.Context, fault *v1alpha1.NodeFault, apply bool, prior map[string]*corev1.NodeCondition) error {
+	cond := fault.Spec.NodeCondition
+	if cond == nil {
+		return nil
+	}
+	nodes, err := selectNodes(ctx, c.conf.ClientSet, fault.Spec.Selector)
+	if err != nil {
+		return err
+	}
+	condType := corev1.NodeConditionType(cond.Type)
+	for _, node := range nodes {
+		if apply {
+			prior[node.Name] = findNodeCondition(node, condType)
+			setNodeCondition(node, condType, corev1.ConditionStatus(cond.Status), cond.Reason, cond.Message)
+		} else if before := prior[node.Name]; before != nil {
+			setNodeCondition(node, condType, before.Status, before.Reason, before.Message)
+		} else {
+			removeNodeCondition(node, condType)
+		}
+		if _, err := c.conf.ClientSet.CoreV1().Nodes().UpdateStatus(ctx, node, metav1.UpdateOptions{}); err != nil {
+			return fmt.Errorf("update node %s: %w", node.Name, err)
+		}
+	}
+	return nil
+}
+
+// applyPodFault applies fault.Spec to every matching pod, or clears it.
+// prior is keyed by pod namespace/name and carries, across the apply/clear
+// pair, the PodCondition that was in place before a PodCondition fault was
+// first applied, so clearing restores it rather than inverting the injected
+// status.
+func (c *Controller) applyPodFault(ctx context.Context, fault *v1alpha1.PodFault, apply bool, prior map[string]*corev1.PodCondition) error {
+	pods, err := selectPods(ctx, c.conf.ClientSet, fault.Spec.Selector)
+	if err != nil {
+		return err
+	}
+	for _, pod := range pods {
+		switch {
+		case fault.Spec.Evict:
+			if !apply {
+				continue
+			}
+			err := c.conf.ClientSet.PolicyV1().Evictions(pod.Namespace).Evict(ctx, &policyv1.Eviction{
+				ObjectMeta: metav1.ObjectMeta{Name: pod.Name, Namespace: pod.Namespace},
+			})
+			if err != nil {
+				return fmt.Errorf("evict pod %s/%s: %w", pod.Namespace, pod.Name, err)
+			}
+		case fault.Spec.PodCondition != nil:
+			cond := fault.Spec.PodCondition
+			condType := corev1.PodConditionType(cond.Type)
+			podKey := key(pod.Namespace, pod.Name)
+			if apply {
+				prior[podKey] = findPodCondition(pod, condType)
+				setPodCondition(pod, condType, corev1.ConditionStatus(cond.Status), cond.Reason, cond.Message)
+			} else if before := prior[podKey]; before != nil {
+				setPodCondition(pod, condType, before.Status, before.Reason, before.Message)
+			} else {
+				removePodCondition(pod, condType)
+			}
+			if _, err := c.conf.ClientSet.CoreV1().Pods(pod.Namespace).UpdateStatus(ctx, pod, metav1.UpdateOptions{}); err != nil {
+				return fmt.Errorf("update pod %s/%s: %w", pod.Namespace, pod.Name, err)
+			}
+		case fault.Spec.ContainerWaiting != nil:
+			setContainerWaiting(pod, fault.Spec.ContainerWaiting, apply)
+			if _, err := c.conf.ClientSet.CoreV1().Pods(pod.Namespace).UpdateStatus(ctx, pod, metav1.UpdateOptions{}); err != nil {
+				return fmt.Errorf("update pod %s/%s: %w", pod.Namespace, pod.Name, err)
+			}
+		}
+	}
+	return nil
+}
+
+func selectNodes(ctx context.Context, clientset kubernetes.Interface, selector *v1alpha1.StageSelector) ([]*corev1.Node, error) {
+	list, err := clientset.CoreV1().Nodes().List(ctx, metav1.ListOptions{LabelSelector: matchLabelsSelector(selector)})
+	if err != nil {
+		return nil, fmt.Errorf("list nodes: %w", err)
+	}
+	nodes := make([]*corev1.Node, 0, len(list.Items))
+	for i := range list.Items {
+		node := &list.Items[i]
+		if matchAnnotations(selector, node.Annotations) {
+			nodes = append(nodes, node)
+		}
+	}
+	return nodes, nil
+}
+
+func selectPods(ctx context.Context, clientset kubernetes.Interface, selector *v1alpha1.StageSelector) ([]*corev1.Pod, error) {
+	list, err := clientset.CoreV1().Pods(metav1.NamespaceAll).List(ctx, metav1.ListOptions{LabelSelector: matchLabelsSelector(selector)})
+	if err != nil {
+		return nil, fmt.Errorf("list pods: %w", err)
+	}
+	pods := make([]*corev1.Pod, 0, len(list.Items))
+	for i := range list.Items {
+		pod := &list.Items[i]
+		if matchAnnotations(selector, pod.Annotations) {
+			pods = append(pods, pod)
+		}
+	}
+	return pods, nil
+}
+
+func matchLabelsSelector(selector *v1alpha1.StageSelector) string {
+	if selector == nil || len(selector.MatchLabels) == 0 {
+		return ""
+	}
+	return labels.SelectorFromSet(selector.MatchLabels).String()
+}
+
+func matchAnnotations(selector *v1alpha1.StageSelector, annotations map[string]string) bool {
+	if selector == nil || len(selector.MatchAnnotations) == 0 {
+		return true
+	}
+	return labels.SelectorFromSet(selector.MatchAnnotations).Matches(labels.Set(annotations))
+}
+
+// key identifies a pod for the prior-condition maps in applyPodFault.
+func key(namespace, name string) string {
+	return namespace + "/" + name
+}
+
+// findNodeCondition returns a copy of node's condition of type condType, or
+// nil if node has none, so a fault clear can restore exactly what was there
+// before the fault was applied.
+func findNodeCondition(node *corev1.Node, condType corev1.NodeConditionType) *corev1.NodeCondition {
+	for i := range node.Status.Conditions {
+		if node.Status.Conditions[i].Type == condType {
+			cond := node.Status.Conditions[i]
+			return &cond
+		}
+	}
+	return nil
+}
+
+// removeNodeCondition deletes node's condition of type condType, used to
+// clear a fault that injected a condition the node never had before.
+func removeNodeCondition(node *corev1.Node, condType corev1.NodeConditionType) {
+	for i := range node.Status.Conditions {
+		if node.Status.Conditions[i].Type == condType {
+			node.Status.Conditions = append(node.Status.Conditions[:i], node.Status.Conditions[i+1:]...)
+			return
+		}
+	}
+}
+
+// findPodCondition returns a copy of pod's condition of type condType, or
+// nil if pod has none, so a fault clear can restore exactly what was there
+// before the fault was applied.
+func findPodCondition(pod *corev1.Pod, condType corev1.PodConditionType) *corev1.PodCondition {
+	for i := range pod.Status.Conditions {
+		if pod.Status.Conditions[i].Type == condType {
+			cond := pod.Status.Conditions[i]
+			return &cond
+		}
+	}
+	return nil
+}
+
+// removePodCondition deletes pod's condition of type condType, used to
+// clear a fault that injected a condition the pod never had before.
+func removePodCondition(pod *corev1.Pod, condType corev1.PodConditionType) {
+	for i := range pod.Status.Conditions {
+		if pod.Status.Conditions[i].Type == condType {
+			pod.Status.Conditions = append(pod.Status.Conditions[:i], pod.Status.Conditions[i+1:]...)
+			return
+		}
+	}
+}
+
+// setNodeCondition sets node's condition of type condType, only bumping
+// LastTransitionTime when the status actually changes.
+func setNodeCondition(node *corev1.Node, condType corev1.NodeConditionType, status corev1.ConditionStatus, reason, message string) {
+	now := metav1.Now()
+	for i := range node.Status.Conditions {
+		if node.Status.Conditions[i].Type == condType {
+			if node.Status.Conditions[i].Status != status {
+				node.Status.Conditions[i].LastTransitionTime = now
+			}
+			node.Status.Conditions[i].Status = status
+			node.Status.Conditions[i].Reason = reason
+			node.Status.Conditions[i].Message = message
+			return
+		}
+	}
+	node.Status.Conditions = append(node.Status.Conditions, corev1.NodeCondition{
+		Type:               condType,
+		Status:             status,
+		Reason:             reason,
+		Message:            message,
+		LastTransitionTime: now,
+	})
+}
+
+// setPodCondition sets pod's condition of type condType, only bumping
+// LastTransitionTime when the status actually changes.
+func setPodCondition(pod *corev1.Pod, condType corev1.PodConditionType, status corev1.ConditionStatus, reason, message string) {
+	now := metav1.Now()
+	for i := range pod.Status.Conditions {
+		if pod.Status.Conditions[i].Type == condType {
+			if pod.Status.Conditions[i].Status != status {
+				pod.Status.Conditions[i].LastTransitionTime = now
+			}
+			pod.Status.Conditions[i].Status = status
+			pod.Status.Conditions[i].Reason = reason
+			pod.Status.Conditions[i].Message = message
+			return
+		}
+	}
+	pod.Status.Conditions = append(pod.Status.Conditions, corev1.PodCondition{
+		Type:               condType,
+		Status:             status,
+		Reason:             reason,
+		Message:            message,
+		LastTransitionTime: now,
+	})
+}
+
+// setContainerWaiting puts the named container (or every container, if
+// ContainerName is empty) into a waiting state when apply is true, or back
+// into a running state when apply is false.
+func setContainerWaiting(pod *corev1.Pod, fault *v1alpha1.FaultContainerWaiting, apply bool) {
+	for i := range pod.Status.ContainerStatuses {
+		status := &pod.Status.ContainerStatuses[i]
+		if fault.ContainerName != "" && status.Name != fault.ContainerName {
+			continue
+		}
+		if apply {
+			status.Ready = false
+			status.State = corev1.ContainerState{
+				Waiting: &corev1.ContainerStateWaiting{
+					Reason:  fault.Reason,
+					Message: fault.Message,
+				},
+			}
+		} else {
+			status.Ready = true
+			status.State = corev1.ContainerState{
+				Running: &corev1.ContainerStateRunning{StartedAt: metav1.Now()},
+			}
+		}
+	}
+}
+
+// LoadRulesFile decodes a --faults-config YAML/JSON document stream into
+// NodeFault and PodFault rules, dispatching on each document's "kind" field.
+func LoadRulesFile(data []byte) (nodeRules []v1alpha1.NodeFault, podRules []v1alpha1.PodFault, err error) {
+	decoder := yaml.NewYAMLOrJSONDecoder(bytes.NewReader(data), 4096)
+	for {
+		var raw json.RawMessage
+		if err := decoder.Decode(&raw); err != nil {
+			if err == io.EOF {
+				break
+			}
+			return nil, nil, fmt.Errorf("decode faults config: %w", err)
+		}
+
+		var meta metav1.TypeMeta
+		if err := json.Unmarshal(raw, &meta); err != nil {
+			return nil, nil, fmt.Errorf("decode faults config: %w", err)
+		}
+
+		switch meta.Kind {
+		case "NodeFault":
+			var rule v1alpha1.NodeFault
+			if err := json.Unmarshal(raw, &rule); err != nil {
+				return nil, nil, fmt.Errorf("decode NodeFault: %w", err)
+			}
+			nodeRules = append(nodeRules, rule)
+		case "PodFault":
+			var rule v1alpha1.PodFault
+			if err := json.Unmarshal(raw, &rule); err != nil {
+				return nil, nil, fmt.Errorf("decode PodFault: %w", err)
+			}
+			podRules = append(podRules, rule)
+		default:
+			return nil, nil, fmt.Errorf("unknown fault kind %q", meta.Kind)
+		}
+	}
+	return nodeRules, podRules, nil
+}
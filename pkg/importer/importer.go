@@ -0,0 +1,210 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package importer snapshots Pods from a real cluster and recreates them
+// against a kwok-managed cluster so the existing PodController can drive
+// their status, without needing real kubelets to schedule them.
+package importer
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+
+	"sigs.k8s.io/kwok/pkg/log"
+)
+
+// NodeMapping maps a source node name to the kwok-managed node it should be
+// rewritten onto. A missing entry makes the import of that Pod fail with an
+// error unless DefaultNode is set; the other Pods are still imported.
+type NodeMapping map[string]string
+
+// Config holds the configuration for an Importer.
+type Config struct {
+	// SourceClientSet is the client for the real cluster Pods are read from.
+	SourceClientSet kubernetes.Interface
+	// DestClientSet is the client for the kwok-managed cluster Pods are recreated in.
+	DestClientSet kubernetes.Interface
+
+	// Namespace restricts the import to a single namespace. Empty means all namespaces.
+	Namespace string
+	// LabelSelector filters the Pods to import.
+	LabelSelector string
+
+	// NodeMapping renames spec.nodeName from the source cluster to a kwok node.
+	NodeMapping NodeMapping
+	// DefaultNode is used for Pods whose source node has no entry in NodeMapping.
+	DefaultNode string
+
+	// PreserveMeta keeps uid and ownerReferences from the source Pod instead
+	// of stripping them before recreating it. resourceVersion is always
+	// cleared.
+	PreserveMeta bool
+
+	// DryRun prints what would be imported without creating anything.
+	DryRun bool
+	// Parallelism is the number of Pods imported concurrently.
+	Parallelism int
+}
+
+// Importer copies Pods from a source cluster into a kwok-managed cluster.
+type Importer struct {
+	conf Config
+}
+
+// NewImporter returns a new Importer for the given Config.
+func NewImporter(conf Config) (*Importer, error) {
+	if conf.SourceClientSet == nil {
+		return nil, fmt.Errorf("source clientset is required")
+	}
+	if conf.DestClientSet == nil {
+		return nil, fmt.Errorf("destination clientset is required")
+	}
+	if conf.Parallelism <= 0 {
+		conf.Parallelism = 1
+	}
+	return &Importer{conf: conf}, nil
+}
+
+// Run lists the matching Pods from the source cluster and recreates them in
+// the destination cluster, remapping spec.nodeName as it goes.
+func (i *Importer) Run(ctx context.Context) error {
+	logger := log.FromContext(ctx)
+
+	pods, err := i.conf.SourceClientSet.CoreV1().Pods(i.conf.Namespace).List(ctx, metav1.ListOptions{
+		LabelSelector: i.conf.LabelSelector,
+	})
+	if err != nil {
+		return fmt.Errorf("list source pods: %w", err)
+	}
+
+	work := make(chan corev1.Pod)
+	var wg sync.WaitGroup
+	errs := make(chan error, len(pods.Items))
+
+	for w := 0; w < i.conf.Parallelism; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for pod := range work {
+				if err := i.importPod(ctx, &pod); err != nil {
+					errs <- fmt.Errorf("import pod %s/%s: %w", pod.Namespace, pod.Name, err)
+				}
+			}
+		}()
+	}
+
+	for _, pod := range pods.Items {
+		if reason, ok := i.unsupported(&pod); ok {
+			logger.Warn("Skipping pod that kwok cannot simulate", "pod", pod.Name, "namespace", pod.Namespace, "reason", reason)
+			continue
+		}
+		work <- pod
+	}
+	close(work)
+	wg.Wait()
+	close(errs)
+
+	var firstErr error
+	for err := range errs {
+		logger.Error("Failed to import pod", err)
+		if firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+// unsupported reports whether a Pod uses a feature kwok cannot fake, such as
+// a volume type or API that has no equivalent in the simulator.
+func (i *Importer) unsupported(pod *corev1.Pod) (string, bool) {
+	for _, vol := range pod.Spec.Volumes {
+		switch {
+		case vol.ConfigMap != nil, vol.Secret != nil, vol.EmptyDir != nil, vol.DownwardAPI != nil, vol.Projected != nil, vol.HostPath != nil:
+			continue
+		default:
+			return fmt.Sprintf("volume %q is not backed by a fake-able source", vol.Name), true
+		}
+	}
+	if len(pod.Spec.EphemeralContainers) > 0 {
+		return "ephemeral containers are not supported", true
+	}
+	return "", false
+}
+
+// targetNode resolves the kwok node a Pod should be rewritten onto.
+func (i *Importer) targetNode(sourceNode string) (string, bool) {
+	if mapped, ok := i.conf.NodeMapping[sourceNode]; ok {
+		return mapped, true
+	}
+	if i.conf.DefaultNode != "" {
+		return i.conf.DefaultNode, true
+	}
+	return "", false
+}
+
+func (i *Importer) importPod(ctx context.Context, pod *corev1.Pod) error {
+	logger := log.FromContext(ctx)
+
+	node, ok := i.targetNode(pod.Spec.NodeName)
+	if !ok {
+		return fmt.Errorf("no node mapping for source node %q", pod.Spec.NodeName)
+	}
+
+	out := pod.DeepCopy()
+	out.Spec.NodeName = node
+	out.Status = corev1.PodStatus{}
+	out.ResourceVersion = ""
+	if !i.conf.PreserveMeta {
+		out.UID = ""
+		out.OwnerReferences = nil
+	}
+
+	if i.conf.DryRun {
+		logger.Info("Would import pod", "pod", out.Name, "namespace", out.Namespace, "sourceNode", pod.Spec.NodeName, "targetNode", node)
+		return nil
+	}
+
+	_, err := i.conf.DestClientSet.CoreV1().Pods(out.Namespace).Create(ctx, out, metav1.CreateOptions{})
+	if err != nil {
+		return err
+	}
+	logger.Info("Imported pod", "pod", out.Name, "namespace", out.Namespace, "sourceNode", pod.Spec.NodeName, "targetNode", node)
+	return nil
+}
+
+// ParseNodeMapping parses a "source=target" per line mapping file into a NodeMapping.
+// Blank lines and lines starting with '#' are ignored.
+func ParseNodeMapping(data []byte) (NodeMapping, error) {
+	mapping := NodeMapping{}
+	for _, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		source, target, ok := strings.Cut(line, "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid mapping line %q, want source=target", line)
+		}
+		mapping[strings.TrimSpace(source)] = strings.TrimSpace(target)
+	}
+	return mapping, nil
+}
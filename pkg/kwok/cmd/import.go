@@ -0,0 +1,127 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"sigs.k8s.io/kwok/pkg/importer"
+	"sigs.k8s.io/kwok/pkg/log"
+	"sigs.k8s.io/kwok/pkg/utils/path"
+)
+
+type importFlagpole struct {
+	SourceKubeconfig string
+	SourceMaster     string
+
+	Namespace     string
+	LabelSelector string
+
+	NodeMappingFile string
+	DefaultNode     string
+	PreserveMeta    bool
+
+	DryRun      bool
+	Parallelism int
+}
+
+// newImportCommand returns a new cobra.Command that snapshots Pods from a
+// real cluster and replays them against the kwok-managed cluster identified
+// by the root command's --kubeconfig/--master flags.
+func newImportCommand(ctx context.Context, dest *flagpole) *cobra.Command {
+	flags := &importFlagpole{}
+
+	cmd := &cobra.Command{
+		Args:          cobra.NoArgs,
+		Use:           "import",
+		Short:         "Import Pods from a real cluster and replay them on kwok-managed nodes",
+		Long:          "Import Pods from a real cluster and replay them on kwok-managed nodes, so the existing PodController drives their status without running real kubelets",
+		SilenceUsage:  true,
+		SilenceErrors: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx := cmd.Context()
+			logger := log.FromContext(ctx)
+
+			if flags.SourceKubeconfig == "" {
+				return fmt.Errorf("--source-kubeconfig is required")
+			}
+			flags.SourceKubeconfig = path.ExpandHome(flags.SourceKubeconfig)
+
+			sourceClientset, err := newClientset(ctx, flags.SourceMaster, flags.SourceKubeconfig)
+			if err != nil {
+				return fmt.Errorf("connect to source cluster: %w", err)
+			}
+
+			destKubeconfig := dest.Kubeconfig
+			if destKubeconfig != "" {
+				destKubeconfig = path.ExpandHome(destKubeconfig)
+			}
+			destClientset, err := newClientset(ctx, dest.Master, destKubeconfig)
+			if err != nil {
+				return fmt.Errorf("connect to destination cluster: %w", err)
+			}
+
+			var mapping importer.NodeMapping
+			if flags.NodeMappingFile != "" {
+				data, err := os.ReadFile(path.ExpandHome(flags.NodeMappingFile))
+				if err != nil {
+					return fmt.Errorf("read node mapping file: %w", err)
+				}
+				mapping, err = importer.ParseNodeMapping(data)
+				if err != nil {
+					return fmt.Errorf("parse node mapping file: %w", err)
+				}
+			}
+
+			imp, err := importer.NewImporter(importer.Config{
+				SourceClientSet: sourceClientset,
+				DestClientSet:   destClientset,
+				Namespace:       flags.Namespace,
+				LabelSelector:   flags.LabelSelector,
+				NodeMapping:     mapping,
+				DefaultNode:     flags.DefaultNode,
+				PreserveMeta:    flags.PreserveMeta,
+				DryRun:          flags.DryRun,
+				Parallelism:     flags.Parallelism,
+			})
+			if err != nil {
+				return err
+			}
+
+			if flags.DryRun {
+				logger.Info("Running in dry-run mode, no pods will be created")
+			}
+			return imp.Run(ctx)
+		},
+	}
+
+	cmd.Flags().StringVar(&flags.SourceKubeconfig, "source-kubeconfig", "", "Path to the kubeconfig file of the cluster to import pods from")
+	cmd.Flags().StringVar(&flags.SourceMaster, "source-master", "", "Server address of the cluster to import pods from")
+	cmd.Flags().StringVar(&flags.Namespace, "namespace", "", "Namespace to import pods from, empty means all namespaces")
+	cmd.Flags().StringVar(&flags.LabelSelector, "label-selector", "", "Label selector used to filter the pods to import")
+	cmd.Flags().StringVar(&flags.NodeMappingFile, "node-mapping-file", "", "Path to a file of \"source-node=target-node\" rename rules, one per line")
+	cmd.Flags().StringVar(&flags.DefaultNode, "default-node", "", "Node to use for pods whose source node has no entry in the node mapping file")
+	cmd.Flags().BoolVar(&flags.PreserveMeta, "preserve-meta", false, "Preserve uid and ownerReferences on the imported pods instead of stripping them; resourceVersion is always cleared")
+	cmd.Flags().BoolVar(&flags.DryRun, "dry-run", false, "Show what would be imported without creating anything")
+	cmd.Flags().IntVar(&flags.Parallelism, "concurrency", 1, "Number of pods imported concurrently")
+
+	return cmd
+}
@@ -19,11 +19,13 @@ package cmd
 
 import (
 	"context"
+	"fmt"
 	"net"
 	"net/http"
 	"os"
 	"time"
 
+	"github.com/prometheus/client_golang/prometheus/collectors"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"github.com/spf13/cobra"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -38,9 +40,11 @@ import (
 	"sigs.k8s.io/kwok/pkg/cni"
 	"sigs.k8s.io/kwok/pkg/config"
 	"sigs.k8s.io/kwok/pkg/consts"
+	"sigs.k8s.io/kwok/pkg/faults"
 	"sigs.k8s.io/kwok/pkg/kwok/controllers"
 	"sigs.k8s.io/kwok/pkg/kwok/controllers/templates"
 	"sigs.k8s.io/kwok/pkg/log"
+	"sigs.k8s.io/kwok/pkg/metrics"
 	"sigs.k8s.io/kwok/pkg/utils/envs"
 	"sigs.k8s.io/kwok/pkg/utils/path"
 )
@@ -49,6 +53,8 @@ type flagpole struct {
 	Kubeconfig string
 	Master     string
 
+	FaultsConfig string
+
 	*internalversion.KwokConfiguration
 }
 
@@ -119,6 +125,8 @@ func NewCommand(ctx context.Context) *cobra.Command {
 				return err
 			}
 
+			metricsRegistry := metrics.NewMetrics()
+
 			ctr, err := controllers.NewController(controllers.Config{
 				ClientSet:                             clientset,
 				EnableCNI:                             flags.Options.EnableCNI,
@@ -132,13 +140,25 @@ func NewCommand(ctx context.Context) *cobra.Command {
 				PodStatusTemplate:                     templates.DefaultPodStatusTemplate,
 				NodeHeartbeatTemplate:                 templates.DefaultNodeHeartbeatTemplate,
 				NodeInitializationTemplate:            templates.DefaultNodeStatusTemplate,
+				MetricsRegistry:                       metricsRegistry,
+				StageLister:                           controllers.NewDefaultStageLister(),
 			})
 			if err != nil {
 				return err
 			}
 
 			if flags.Options.ServerAddress != "" {
-				go Serve(ctx, flags.Options.ServerAddress)
+				go Serve(ctx, flags.Options.ServerAddress, metricsRegistry)
+			}
+
+			if flags.FaultsConfig != "" {
+				faultsCtr, err := newFaultsController(flags.FaultsConfig, clientset)
+				if err != nil {
+					return err
+				}
+				if err := faultsCtr.Start(ctx); err != nil {
+					return err
+				}
 			}
 
 			err = ctr.Start(ctx)
@@ -160,19 +180,44 @@ func NewCommand(ctx context.Context) *cobra.Command {
 	cmd.Flags().StringVar(&flags.Options.ManageNodesWithLabelSelector, "manage-nodes-with-label-selector", flags.Options.ManageNodesWithLabelSelector, "Nodes that match the label selector will be watched and managed. It's conflicted with manage-all-nodes.")
 	cmd.Flags().StringVar(&flags.Options.DisregardStatusWithAnnotationSelector, "disregard-status-with-annotation-selector", flags.Options.DisregardStatusWithAnnotationSelector, "All node/pod status excluding the ones that match the annotation selector will be watched and managed.")
 	cmd.Flags().StringVar(&flags.Options.DisregardStatusWithLabelSelector, "disregard-status-with-label-selector", flags.Options.DisregardStatusWithLabelSelector, "All node/pod status excluding the ones that match the label selector will be watched and managed.")
-	cmd.Flags().StringVar(&flags.Kubeconfig, "kubeconfig", flags.Kubeconfig, "Path to the kubeconfig file to use")
-	cmd.Flags().StringVar(&flags.Master, "master", flags.Master, "Server is the address of the kubernetes cluster")
+	cmd.PersistentFlags().StringVar(&flags.Kubeconfig, "kubeconfig", flags.Kubeconfig, "Path to the kubeconfig file to use")
+	cmd.PersistentFlags().StringVar(&flags.Master, "master", flags.Master, "Server is the address of the kubernetes cluster")
 	cmd.Flags().StringVar(&flags.Options.ServerAddress, "server-address", flags.Options.ServerAddress, "Address to expose health and metrics on")
+	cmd.Flags().StringVar(&flags.FaultsConfig, "faults-config", flags.FaultsConfig, "Path to a YAML file of NodeFault/PodFault rules to inject")
 
 	if cni.SupportedCNI() {
 		cmd.Flags().BoolVar(&flags.Options.EnableCNI, "experimental-enable-cni", flags.Options.EnableCNI, "Experimental support for getting pod ip from CNI, for CNI-related components")
 	}
+
+	cmd.AddCommand(newImportCommand(ctx, flags))
+	cmd.AddCommand(newApplyCommand(ctx))
 	return cmd
 }
 
-func Serve(ctx context.Context, address string) {
+// newFaultsController loads the --faults-config file and returns a
+// faults.Controller ready to be started.
+func newFaultsController(faultsConfig string, clientset kubernetes.Interface) (*faults.Controller, error) {
+	data, err := os.ReadFile(path.ExpandHome(faultsConfig))
+	if err != nil {
+		return nil, fmt.Errorf("read faults config: %w", err)
+	}
+	nodeRules, podRules, err := faults.LoadRulesFile(data)
+	if err != nil {
+		return nil, err
+	}
+	return faults.NewController(faults.Config{
+		ClientSet: clientset,
+		NodeRules: nodeRules,
+		PodRules:  podRules,
+	})
+}
+
+func Serve(ctx context.Context, address string, metricsRegistry *metrics.Metrics) {
 	logger := log.FromContext(ctx)
-	promHandler := promhttp.Handler()
+	registry := metricsRegistry.Registry()
+	registry.MustRegister(collectors.NewGoCollector())
+	registry.MustRegister(collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}))
+	promHandler := promhttp.HandlerFor(registry, promhttp.HandlerOpts{})
 	svc := &http.Server{
 		ReadHeaderTimeout: 5 * time.Second,
 		BaseContext: func(_ net.Listener) context.Context {
@@ -0,0 +1,118 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package controllers wires the NodeController and PodController that
+// simulate a cluster full of fake kubelets.
+package controllers
+
+import (
+	"context"
+
+	"k8s.io/client-go/kubernetes"
+
+	"sigs.k8s.io/kwok/pkg/metrics"
+)
+
+// Config holds the configuration for the top-level Controller, which wires
+// together a NodeController and a PodController.
+type Config struct {
+	ClientSet kubernetes.Interface
+
+	EnableCNI                         bool
+	ManageAllNodes                    bool
+	ManageNodesWithAnnotationSelector string
+	ManageNodesWithLabelSelector      string
+
+	DisregardStatusWithAnnotationSelector string
+	DisregardStatusWithLabelSelector      string
+
+	CIDR   string
+	NodeIP string
+
+	// PodStatusTemplate is the legacy, template-based pod status, used only
+	// when Provider and StageLister are both unset. NodeHeartbeatTemplate and
+	// NodeInitializationTemplate are passed to the NodeController, which does
+	// not use them.
+	PodStatusTemplate          string
+	NodeHeartbeatTemplate      string
+	NodeInitializationTemplate string
+
+	// Provider, when set, replaces PodStatusTemplate entirely as the source
+	// of Pod status. Takes precedence over StageLister.
+	Provider Provider
+	// StageLister, when set and Provider is unset, drives pod status from
+	// CRD-defined Stage/StageSet objects instead of PodStatusTemplate.
+	StageLister StageLister
+
+	// MetricsRegistry, when set, is incremented from the NodeController and
+	// PodController reconcile paths.
+	MetricsRegistry *metrics.Metrics
+}
+
+// Controller runs a NodeController and a PodController together.
+type Controller struct {
+	nodeController *NodeController
+	podController  *PodController
+}
+
+// NewController wires a NodeController and a PodController from conf.
+func NewController(conf Config) (*Controller, error) {
+	nodeController, err := NewNodeController(NodeControllerConfig{
+		ClientSet:                         conf.ClientSet,
+		ManageAllNodes:                    conf.ManageAllNodes,
+		ManageNodesWithAnnotationSelector: conf.ManageNodesWithAnnotationSelector,
+		ManageNodesWithLabelSelector:      conf.ManageNodesWithLabelSelector,
+		HeartbeatTemplate:                 conf.NodeHeartbeatTemplate,
+		InitializationTemplate:            conf.NodeInitializationTemplate,
+		FuncMap:                           funcMap,
+		MetricsRegistry:                   conf.MetricsRegistry,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	podController, err := NewPodController(PodControllerConfig{
+		ClientSet:                             conf.ClientSet,
+		NodeIP:                                conf.NodeIP,
+		CIDR:                                  conf.CIDR,
+		DisregardStatusWithAnnotationSelector: conf.DisregardStatusWithAnnotationSelector,
+		DisregardStatusWithLabelSelector:      conf.DisregardStatusWithLabelSelector,
+		PodStatusTemplate:                     conf.PodStatusTemplate,
+		FuncMap:                               funcMap,
+		Provider:                              conf.Provider,
+		StageLister:                           conf.StageLister,
+		NodeHasFunc:                           nodeController.Has,
+		LockPodParallelism:                    4,
+		DeletePodParallelism:                  4,
+		MetricsRegistry:                       conf.MetricsRegistry,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &Controller{
+		nodeController: nodeController,
+		podController:  podController,
+	}, nil
+}
+
+// Start starts the NodeController and the PodController.
+func (c *Controller) Start(ctx context.Context) error {
+	if err := c.nodeController.Start(ctx); err != nil {
+		return err
+	}
+	return c.podController.Start(ctx)
+}
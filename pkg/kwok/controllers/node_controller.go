@@ -0,0 +1,190 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"text/template"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/kubernetes"
+
+	"sigs.k8s.io/kwok/pkg/log"
+	"sigs.k8s.io/kwok/pkg/metrics"
+)
+
+// defaultHeartbeatInterval is how often a managed node's Ready condition is refreshed.
+const defaultHeartbeatInterval = 10 * time.Second
+
+// NodeControllerConfig holds the configuration for a NodeController.
+type NodeControllerConfig struct {
+	ClientSet kubernetes.Interface
+
+	ManageAllNodes                    bool
+	ManageNodesWithAnnotationSelector string
+	ManageNodesWithLabelSelector      string
+
+	// HeartbeatTemplate and InitializationTemplate are the legacy,
+	// template-based node status rendering. NodeController does not use them,
+	// nor Provider.NodeConditions: its heartbeat only refreshes or creates
+	// the Ready condition (see setNodeHeartbeat).
+	HeartbeatTemplate      string
+	InitializationTemplate string
+	FuncMap                template.FuncMap
+
+	HeartbeatInterval time.Duration
+
+	MetricsRegistry *metrics.Metrics
+}
+
+// NodeController tracks which Nodes kwok manages and keeps their heartbeat fresh.
+type NodeController struct {
+	conf NodeControllerConfig
+
+	mut     sync.RWMutex
+	managed map[string]bool
+}
+
+// NewNodeController returns a new NodeController.
+func NewNodeController(conf NodeControllerConfig) (*NodeController, error) {
+	if conf.ClientSet == nil {
+		return nil, fmt.Errorf("clientset is required")
+	}
+	if conf.HeartbeatInterval <= 0 {
+		conf.HeartbeatInterval = defaultHeartbeatInterval
+	}
+	return &NodeController{
+		conf:    conf,
+		managed: map[string]bool{},
+	}, nil
+}
+
+// Has reports whether nodeName is currently managed by this controller.
+func (c *NodeController) Has(nodeName string) bool {
+	c.mut.RLock()
+	defer c.mut.RUnlock()
+	return c.managed[nodeName]
+}
+
+// manages reports whether node matches this controller's node selection.
+func (c *NodeController) manages(node *corev1.Node) bool {
+	if c.conf.ManageAllNodes {
+		return true
+	}
+	if sel := c.conf.ManageNodesWithAnnotationSelector; sel != "" {
+		selector, err := labels.Parse(sel)
+		if err == nil && selector.Matches(labels.Set(node.Annotations)) {
+			return true
+		}
+	}
+	if sel := c.conf.ManageNodesWithLabelSelector; sel != "" {
+		selector, err := labels.Parse(sel)
+		if err == nil && selector.Matches(labels.Set(node.Labels)) {
+			return true
+		}
+	}
+	return false
+}
+
+// Start lists the nodes this kwok instance manages and begins sending them
+// periodic heartbeats.
+func (c *NodeController) Start(ctx context.Context) error {
+	list, err := c.conf.ClientSet.CoreV1().Nodes().List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return fmt.Errorf("list nodes: %w", err)
+	}
+
+	c.mut.Lock()
+	for i := range list.Items {
+		if node := &list.Items[i]; c.manages(node) {
+			c.managed[node.Name] = true
+		}
+	}
+	count := len(c.managed)
+	c.mut.Unlock()
+
+	if c.conf.MetricsRegistry != nil {
+		c.conf.MetricsRegistry.ManagedNodes.Set(float64(count))
+	}
+
+	go c.heartbeatLoop(ctx)
+	return nil
+}
+
+func (c *NodeController) heartbeatLoop(ctx context.Context) {
+	logger := log.FromContext(ctx)
+	ticker := time.NewTicker(c.conf.HeartbeatInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			c.heartbeat(ctx, logger)
+		}
+	}
+}
+
+func (c *NodeController) heartbeat(ctx context.Context, logger log.Logger) {
+	c.mut.RLock()
+	names := make([]string, 0, len(c.managed))
+	for name := range c.managed {
+		names = append(names, name)
+	}
+	c.mut.RUnlock()
+
+	for _, name := range names {
+		node, err := c.conf.ClientSet.CoreV1().Nodes().Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			logger.Error("get node", err)
+			continue
+		}
+		setNodeHeartbeat(node)
+		if _, err := c.conf.ClientSet.CoreV1().Nodes().UpdateStatus(ctx, node, metav1.UpdateOptions{}); err != nil {
+			logger.Error("update node status", err)
+			continue
+		}
+		if c.conf.MetricsRegistry != nil {
+			c.conf.MetricsRegistry.NodeHeartbeatTotal.Inc()
+		}
+	}
+}
+
+// setNodeHeartbeat refreshes the Ready condition's heartbeat time, creating
+// it if the node has none yet.
+func setNodeHeartbeat(node *corev1.Node) {
+	now := metav1.Now()
+	for i := range node.Status.Conditions {
+		if node.Status.Conditions[i].Type == corev1.NodeReady {
+			node.Status.Conditions[i].LastHeartbeatTime = now
+			return
+		}
+	}
+	node.Status.Conditions = append(node.Status.Conditions, corev1.NodeCondition{
+		Type:               corev1.NodeReady,
+		Status:             corev1.ConditionTrue,
+		Reason:             "KubeletReady",
+		Message:            "kubelet is posting ready status",
+		LastHeartbeatTime:  now,
+		LastTransitionTime: now,
+	})
+}
@@ -0,0 +1,363 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"text/template"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/kubernetes"
+
+	"sigs.k8s.io/kwok/pkg/log"
+	"sigs.k8s.io/kwok/pkg/metrics"
+)
+
+// funcMap is the set of template functions available to PodStatusTemplate.
+var funcMap = template.FuncMap{
+	"Now": metav1.Now,
+}
+
+// PodControllerConfig holds the configuration for a PodController.
+type PodControllerConfig struct {
+	ClientSet kubernetes.Interface
+
+	NodeIP string
+	CIDR   string
+
+	DisregardStatusWithAnnotationSelector string
+	DisregardStatusWithLabelSelector      string
+
+	// PodStatusTemplate and FuncMap build the default TemplateProvider, used
+	// when Provider and StageLister are both unset.
+	PodStatusTemplate string
+	FuncMap           template.FuncMap
+
+	// Provider decides what status to report for each managed pod. It takes
+	// precedence over StageLister, which in turn takes precedence over
+	// PodStatusTemplate.
+	Provider Provider
+	// StageLister, when set and Provider is unset, drives pod status from
+	// CRD-defined Stage/StageSet objects instead of PodStatusTemplate.
+	StageLister StageLister
+
+	// NodeHasFunc reports whether a node name belongs to a node this kwok
+	// instance manages; pods scheduled elsewhere are left untouched.
+	NodeHasFunc func(nodeName string) bool
+
+	LockPodParallelism   int
+	DeletePodParallelism int
+
+	MetricsRegistry *metrics.Metrics
+}
+
+// managedPodLabels is the set of label values a PodController last reported
+// for a Pod's ManagedPods gauge series, so the series can be cleared on
+// transition or delete instead of accumulating stale entries.
+type managedPodLabels struct {
+	node      string
+	namespace string
+	phase     string
+}
+
+// PodController manages the status of Pods scheduled onto kwok-managed nodes.
+type PodController struct {
+	conf     PodControllerConfig
+	provider Provider
+
+	mut              sync.Mutex
+	managedPodLabels map[string]managedPodLabels
+}
+
+// NewPodController returns a new PodController for the given config.
+func NewPodController(conf PodControllerConfig) (*PodController, error) {
+	if conf.ClientSet == nil {
+		return nil, fmt.Errorf("clientset is required")
+	}
+	if conf.NodeHasFunc == nil {
+		return nil, fmt.Errorf("NodeHasFunc is required")
+	}
+	if conf.LockPodParallelism <= 0 {
+		conf.LockPodParallelism = 1
+	}
+	if conf.DeletePodParallelism <= 0 {
+		conf.DeletePodParallelism = 1
+	}
+
+	provider, err := newProvider(conf)
+	if err != nil {
+		return nil, err
+	}
+
+	return &PodController{
+		conf:             conf,
+		provider:         provider,
+		managedPodLabels: map[string]managedPodLabels{},
+	}, nil
+}
+
+// newProvider resolves the Provider a PodController should drive status
+// from: an explicit Provider, else a StageLister, else the legacy
+// PodStatusTemplate.
+func newProvider(conf PodControllerConfig) (Provider, error) {
+	if conf.Provider != nil {
+		return conf.Provider, nil
+	}
+	fm := conf.FuncMap
+	if fm == nil {
+		fm = funcMap
+	}
+	if conf.StageLister != nil {
+		return NewStageProvider(conf.StageLister, fm, nil), nil
+	}
+	if conf.PodStatusTemplate == "" {
+		return nil, fmt.Errorf("one of Provider, StageLister or PodStatusTemplate is required")
+	}
+	return NewTemplateProvider(conf.PodStatusTemplate, fm, nil)
+}
+
+// Start lists the pods currently scheduled onto managed nodes, applies the
+// Provider to each, then watches for further changes.
+func (c *PodController) Start(ctx context.Context) error {
+	lockWork := make(chan *corev1.Pod)
+	deleteWork := make(chan *corev1.Pod)
+
+	for i := 0; i < c.conf.LockPodParallelism; i++ {
+		go c.lockWorker(ctx, lockWork)
+	}
+	for i := 0; i < c.conf.DeletePodParallelism; i++ {
+		go c.deleteWorker(ctx, deleteWork)
+	}
+
+	list, err := c.conf.ClientSet.CoreV1().Pods(metav1.NamespaceAll).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return fmt.Errorf("list pods: %w", err)
+	}
+	for i := range list.Items {
+		c.dispatch(ctx, &list.Items[i], lockWork, deleteWork)
+	}
+
+	watcher, err := c.conf.ClientSet.CoreV1().Pods(metav1.NamespaceAll).Watch(ctx, metav1.ListOptions{
+		ResourceVersion: list.ResourceVersion,
+	})
+	if err != nil {
+		return fmt.Errorf("watch pods: %w", err)
+	}
+
+	go func() {
+		defer watcher.Stop()
+		logger := log.FromContext(ctx)
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case event, ok := <-watcher.ResultChan():
+				if !ok {
+					return
+				}
+				pod, ok := event.Object.(*corev1.Pod)
+				if !ok {
+					continue
+				}
+				if event.Type == watch.Deleted {
+					if err := c.provider.DeletePod(ctx, pod); err != nil {
+						logger.Error("provider delete pod", err)
+					}
+					c.clearManagedPodGauge(pod.Namespace, pod.Name)
+					c.incLifecycleEvent(metrics.PodLifecycleEventDelete)
+					continue
+				}
+				c.dispatch(ctx, pod, lockWork, deleteWork)
+			}
+		}
+	}()
+
+	return nil
+}
+
+// dispatch routes a pod to the delete worker pool if it has been deleted
+// out from under kwok, or to the lock worker pool to have its status
+// rendered, skipping pods kwok does not manage or has been told to
+// disregard.
+func (c *PodController) dispatch(ctx context.Context, pod *corev1.Pod, lockWork, deleteWork chan<- *corev1.Pod) {
+	if !c.conf.NodeHasFunc(pod.Spec.NodeName) {
+		return
+	}
+	if pod.DeletionTimestamp != nil {
+		select {
+		case deleteWork <- pod:
+		case <-ctx.Done():
+		}
+		return
+	}
+	if c.disregard(pod) {
+		return
+	}
+	select {
+	case lockWork <- pod:
+	case <-ctx.Done():
+	}
+}
+
+// disregard reports whether pod matches the annotation/label selectors that
+// tell kwok to leave its status alone, e.g. because a test is manually
+// driving it.
+func (c *PodController) disregard(pod *corev1.Pod) bool {
+	if sel := c.conf.DisregardStatusWithAnnotationSelector; sel != "" {
+		selector, err := labels.Parse(sel)
+		if err == nil && selector.Matches(labels.Set(pod.Annotations)) {
+			return true
+		}
+	}
+	if sel := c.conf.DisregardStatusWithLabelSelector; sel != "" {
+		selector, err := labels.Parse(sel)
+		if err == nil && selector.Matches(labels.Set(pod.Labels)) {
+			return true
+		}
+	}
+	return false
+}
+
+func (c *PodController) lockWorker(ctx context.Context, work <-chan *corev1.Pod) {
+	logger := log.FromContext(ctx)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case pod := <-work:
+			c.managePod(ctx, pod, logger)
+		}
+	}
+}
+
+// managePod asks the Provider for the pod's status and patches it, timing
+// the round trip for PodLockDurationSeconds.
+func (c *PodController) managePod(ctx context.Context, pod *corev1.Pod, logger log.Logger) {
+	start := time.Now()
+
+	var err error
+	event := metrics.PodLifecycleEventUpdate
+	if pod.Status.Phase == "" {
+		event = metrics.PodLifecycleEventCreate
+		err = c.provider.CreatePod(ctx, pod)
+	} else {
+		err = c.provider.UpdatePod(ctx, pod)
+	}
+	if err != nil {
+		logger.Error("provider render pod status", err)
+		if c.conf.MetricsRegistry != nil {
+			c.conf.MetricsRegistry.TemplateRenderErrorsTotal.Inc()
+		}
+		return
+	}
+	c.incLifecycleEvent(event)
+
+	status, err := c.provider.GetPodStatus(ctx, pod.Namespace, pod.Name)
+	if err != nil {
+		logger.Error("provider get pod status", err)
+		return
+	}
+
+	updated := pod.DeepCopy()
+	updated.Status = *status
+	if _, err := c.conf.ClientSet.CoreV1().Pods(updated.Namespace).UpdateStatus(ctx, updated, metav1.UpdateOptions{}); err != nil {
+		if !apierrors.IsConflict(err) {
+			logger.Error("update pod status", err)
+		}
+		return
+	}
+
+	c.incLifecycleEvent(metrics.PodLifecycleEventLock)
+	if c.conf.MetricsRegistry != nil {
+		c.conf.MetricsRegistry.PodLockDurationSeconds.Observe(time.Since(start).Seconds())
+		c.setManagedPodGauge(updated.Namespace, updated.Name, updated.Spec.NodeName, string(status.Phase))
+	}
+}
+
+// setManagedPodGauge records pod's ManagedPods series as the given node,
+// namespace and phase, clearing the pod's previously-reported series first
+// if the labels changed so a phase transition does not leave a stale series
+// behind.
+func (c *PodController) setManagedPodGauge(namespace, name, node, phase string) {
+	podKey := key(namespace, name)
+
+	c.mut.Lock()
+	prev, ok := c.managedPodLabels[podKey]
+	next := managedPodLabels{node: node, namespace: namespace, phase: phase}
+	c.managedPodLabels[podKey] = next
+	c.mut.Unlock()
+
+	if ok && prev != next {
+		c.conf.MetricsRegistry.ManagedPods.DeleteLabelValues(prev.node, prev.namespace, prev.phase)
+	}
+	c.conf.MetricsRegistry.ManagedPods.WithLabelValues(node, namespace, phase).Set(1)
+}
+
+// clearManagedPodGauge removes the pod's ManagedPods series, if one was
+// ever reported, once the pod has been deleted.
+func (c *PodController) clearManagedPodGauge(namespace, name string) {
+	if c.conf.MetricsRegistry == nil {
+		return
+	}
+
+	podKey := key(namespace, name)
+
+	c.mut.Lock()
+	prev, ok := c.managedPodLabels[podKey]
+	delete(c.managedPodLabels, podKey)
+	c.mut.Unlock()
+
+	if ok {
+		c.conf.MetricsRegistry.ManagedPods.DeleteLabelValues(prev.node, prev.namespace, prev.phase)
+	}
+}
+
+func (c *PodController) deleteWorker(ctx context.Context, work <-chan *corev1.Pod) {
+	logger := log.FromContext(ctx)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case pod := <-work:
+			// There is no real kubelet to acknowledge the deletion, so kwok
+			// finalizes it itself once the grace period has been requested.
+			err := c.conf.ClientSet.CoreV1().Pods(pod.Namespace).Delete(ctx, pod.Name, metav1.DeleteOptions{})
+			if err != nil && !apierrors.IsNotFound(err) {
+				logger.Error("delete pod", err)
+				continue
+			}
+			if err := c.provider.DeletePod(ctx, pod); err != nil {
+				logger.Error("provider delete pod", err)
+			}
+			c.clearManagedPodGauge(pod.Namespace, pod.Name)
+			c.incLifecycleEvent(metrics.PodLifecycleEventDelete)
+		}
+	}
+}
+
+func (c *PodController) incLifecycleEvent(event metrics.PodLifecycleEvent) {
+	if c.conf.MetricsRegistry != nil {
+		c.conf.MetricsRegistry.IncPodLifecycleEvent(event)
+	}
+}
@@ -192,3 +192,101 @@ func TestPodController(t *testing.T) {
 		}
 	}
 }
+
+// fixedStatusProvider is a Provider that always reports the same status,
+// used to prove PodController only talks to Provider and not to the
+// template machinery directly.
+type fixedStatusProvider struct {
+	status corev1.PodStatus
+}
+
+func (p *fixedStatusProvider) CreatePod(ctx context.Context, pod *corev1.Pod) error { return nil }
+func (p *fixedStatusProvider) UpdatePod(ctx context.Context, pod *corev1.Pod) error { return nil }
+func (p *fixedStatusProvider) DeletePod(ctx context.Context, pod *corev1.Pod) error { return nil }
+
+func (p *fixedStatusProvider) GetPodStatus(ctx context.Context, namespace, name string) (*corev1.PodStatus, error) {
+	status := p.status.DeepCopy()
+	return status, nil
+}
+
+func (p *fixedStatusProvider) NodeConditions(ctx context.Context) []corev1.NodeCondition {
+	return []corev1.NodeCondition{
+		{Type: corev1.NodeReady, Status: corev1.ConditionTrue},
+	}
+}
+
+func TestPodControllerWithCustomProvider(t *testing.T) {
+	clientset := fake.NewSimpleClientset(
+		&corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:              "pod0",
+				Namespace:         "default",
+				CreationTimestamp: metav1.Now(),
+			},
+			Spec: corev1.PodSpec{
+				Containers: []corev1.Container{
+					{
+						Name:  "test-container",
+						Image: "test-image",
+					},
+				},
+				NodeName: "node0",
+			},
+		},
+	)
+
+	nodeHasFunc := func(nodeName string) bool {
+		return strings.HasPrefix(nodeName, "node")
+	}
+
+	provider := &fixedStatusProvider{
+		status: corev1.PodStatus{
+			Phase:  corev1.PodRunning,
+			Reason: "FromCustomProvider",
+		},
+	}
+
+	pods, err := NewPodController(PodControllerConfig{
+		ClientSet:            clientset,
+		NodeIP:               "10.0.0.1",
+		CIDR:                 "10.0.0.1/24",
+		NodeHasFunc:          nodeHasFunc,
+		Provider:             provider,
+		LockPodParallelism:   2,
+		DeletePodParallelism: 2,
+	})
+	if err != nil {
+		t.Fatal(fmt.Errorf("new pods controller error: %w", err))
+	}
+
+	ctx := context.Background()
+	ctx = log.NewContext(ctx, log.NewLogger(os.Stderr, log.DebugLevel))
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	t.Cleanup(func() {
+		cancel()
+		time.Sleep(time.Second)
+	})
+
+	err = pods.Start(ctx)
+	if err != nil {
+		t.Fatal(fmt.Errorf("start pods controller error: %w", err))
+	}
+
+	var pod0 *corev1.Pod
+	err = wait.PollUntilWithContext(ctx, time.Second, func(ctx context.Context) (done bool, err error) {
+		pod0, err = clientset.CoreV1().Pods("default").Get(ctx, "pod0", metav1.GetOptions{})
+		if err != nil {
+			return false, err
+		}
+		if pod0.Status.Reason != "FromCustomProvider" {
+			return false, nil
+		}
+		return true, nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pod0.Status.Phase != corev1.PodRunning {
+		t.Fatal(fmt.Errorf("want pod0 phase is running, got %s", pod0.Status.Phase))
+	}
+}
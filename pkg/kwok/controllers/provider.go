@@ -0,0 +1,127 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"sync"
+	"text/template"
+
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/yaml"
+)
+
+// Provider decides what status a managed Pod should have. It separates the
+// watch loop / client / parallelism plumbing in PodController from the
+// decision of what to report, so users can write Go-native kwok extensions
+// (replaying recorded traces, simulating GPU nodes, talking to an external
+// model) without forking kwok or writing Go templates.
+type Provider interface {
+	// CreatePod is called when PodController starts managing a new Pod.
+	CreatePod(ctx context.Context, pod *corev1.Pod) error
+	// UpdatePod is called when a managed Pod is updated.
+	UpdatePod(ctx context.Context, pod *corev1.Pod) error
+	// DeletePod is called when a managed Pod is deleted.
+	DeletePod(ctx context.Context, pod *corev1.Pod) error
+	// GetPodStatus returns the status PodController should set on the named Pod.
+	GetPodStatus(ctx context.Context, namespace, name string) (*corev1.PodStatus, error)
+	// NodeConditions returns the Node conditions this Provider reports for a
+	// node it is managing, e.g. Ready=True.
+	NodeConditions(ctx context.Context) []corev1.NodeCondition
+}
+
+// TemplateProvider is the default Provider: it renders PodStatusTemplate, a
+// Go template producing a Pod status patch, the same way kwok has always
+// behaved.
+type TemplateProvider struct {
+	template       *template.Template
+	nodeConditions []corev1.NodeCondition
+
+	mut      sync.RWMutex
+	statuses map[string]*corev1.PodStatus
+}
+
+// NewTemplateProvider parses podStatusTemplate with funcMap and returns a
+// Provider that renders it for every managed Pod.
+func NewTemplateProvider(podStatusTemplate string, funcMap template.FuncMap, nodeConditions []corev1.NodeCondition) (*TemplateProvider, error) {
+	tpl, err := template.New("pod-status").Funcs(funcMap).Parse(podStatusTemplate)
+	if err != nil {
+		return nil, fmt.Errorf("parse pod status template: %w", err)
+	}
+	return &TemplateProvider{
+		template:       tpl,
+		nodeConditions: nodeConditions,
+		statuses:       map[string]*corev1.PodStatus{},
+	}, nil
+}
+
+// CreatePod renders the template for pod and caches the resulting status.
+func (p *TemplateProvider) CreatePod(ctx context.Context, pod *corev1.Pod) error {
+	return p.render(pod)
+}
+
+// UpdatePod re-renders the template for pod and refreshes the cached status.
+func (p *TemplateProvider) UpdatePod(ctx context.Context, pod *corev1.Pod) error {
+	return p.render(pod)
+}
+
+// DeletePod forgets the cached status for pod.
+func (p *TemplateProvider) DeletePod(ctx context.Context, pod *corev1.Pod) error {
+	p.mut.Lock()
+	defer p.mut.Unlock()
+	delete(p.statuses, key(pod.Namespace, pod.Name))
+	return nil
+}
+
+// GetPodStatus returns the cached status produced by the last render.
+func (p *TemplateProvider) GetPodStatus(ctx context.Context, namespace, name string) (*corev1.PodStatus, error) {
+	p.mut.RLock()
+	defer p.mut.RUnlock()
+	status, ok := p.statuses[key(namespace, name)]
+	if !ok {
+		return nil, fmt.Errorf("no status rendered for pod %s/%s", namespace, name)
+	}
+	return status, nil
+}
+
+// NodeConditions returns the static node conditions this provider was
+// constructed with.
+func (p *TemplateProvider) NodeConditions(ctx context.Context) []corev1.NodeCondition {
+	return p.nodeConditions
+}
+
+func (p *TemplateProvider) render(pod *corev1.Pod) error {
+	var buf bytes.Buffer
+	if err := p.template.Execute(&buf, pod); err != nil {
+		return fmt.Errorf("render pod status template for %s/%s: %w", pod.Namespace, pod.Name, err)
+	}
+	status := &corev1.PodStatus{}
+	if err := yaml.Unmarshal(buf.Bytes(), status); err != nil {
+		return fmt.Errorf("decode rendered pod status for %s/%s: %w", pod.Namespace, pod.Name, err)
+	}
+
+	p.mut.Lock()
+	defer p.mut.Unlock()
+	p.statuses[key(pod.Namespace, pod.Name)] = status
+	return nil
+}
+
+func key(namespace, name string) string {
+	return namespace + "/" + name
+}
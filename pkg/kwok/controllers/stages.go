@@ -0,0 +1,417 @@
+/*
+Copyright 2022 The Kubernetes Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"math/rand"
+	"sync"
+	"text/template"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"sigs.k8s.io/yaml"
+
+	"sigs.k8s.io/kwok/pkg/apis/v1alpha1"
+)
+
+// StageLister lists the StageSets available to the PodController. The
+// implementations in this package (NewStaticStageLister,
+// NewDefaultStageLister) serve a fixed, in-memory set; none of them watches
+// the kwok.x-k8s.io StageSet CRD.
+type StageLister interface {
+	// List returns every known StageSet.
+	List() []*v1alpha1.StageSet
+}
+
+// defaultNormalStatusTemplate reproduces, as a Stage's StatusTemplate, the
+// status kwok's legacy PodStatusTemplate rendered for every managed Pod:
+// Running, with the standard conditions True, a StartTime, and a Running,
+// Ready status for every container the Pod declares.
+const defaultNormalStatusTemplate = `
+{{- $now := (Now).UTC.Format "2006-01-02T15:04:05Z07:00" }}
+phase: Running
+hostIP: {{ .Status.HostIP }}
+podIP: {{ .Status.PodIP }}
+startTime: {{ $now }}
+conditions:
+- type: Initialized
+  status: "True"
+  lastTransitionTime: {{ $now }}
+- type: Ready
+  status: "True"
+  lastTransitionTime: {{ $now }}
+- type: ContainersReady
+  status: "True"
+  lastTransitionTime: {{ $now }}
+- type: PodScheduled
+  status: "True"
+  lastTransitionTime: {{ $now }}
+containerStatuses:
+{{- range .Spec.Containers }}
+- name: {{ .Name }}
+  image: {{ .Image }}
+  ready: true
+  started: true
+  state:
+    running:
+      startedAt: {{ $now }}
+{{- end }}
+`
+
+// DefaultStageSets are the built-in StageSets matching kwok's original,
+// hard-coded template-based behavior, kept for backwards compatibility with
+// users who never define their own Stage/StageSet objects.
+var DefaultStageSets = map[string]*v1alpha1.StageSet{
+	"normal": {
+		Spec: v1alpha1.StageSetSpec{
+			Stages: []v1alpha1.StageSpec{
+				{
+					Next: v1alpha1.StageNext{
+						StatusTemplate: defaultNormalStatusTemplate,
+					},
+					Weight: 1,
+				},
+			},
+		},
+	},
+}
+
+// staticStageLister is a StageLister over a fixed, in-memory set of
+// StageSets, used when no StageSet CRs have been loaded from a cluster or
+// local files.
+type staticStageLister struct {
+	stageSets []*v1alpha1.StageSet
+}
+
+// NewStaticStageLister returns a StageLister over the given StageSets.
+func NewStaticStageLister(stageSets []*v1alpha1.StageSet) StageLister {
+	return &staticStageLister{stageSets: stageSets}
+}
+
+// NewDefaultStageLister returns a StageLister over DefaultStageSets.
+func NewDefaultStageLister() StageLister {
+	stageSets := make([]*v1alpha1.StageSet, 0, len(DefaultStageSets))
+	for _, s := range DefaultStageSets {
+		stageSets = append(stageSets, s)
+	}
+	return NewStaticStageLister(stageSets)
+}
+
+func (s *staticStageLister) List() []*v1alpha1.StageSet {
+	return s.stageSets
+}
+
+// matchStage reports whether a Stage's selector matches the given Pod.
+// CEL is not evaluated here: this package has no CEL environment to
+// evaluate it against, and StageSelector.CEL takes precedence over
+// MatchLabels/MatchAnnotations, so a Stage that sets it can never be
+// honestly matched by this function. Rather than silently treating it as
+// match-all, such a Stage is never selected.
+func matchStage(selector *v1alpha1.StageSelector, pod *corev1.Pod) bool {
+	if selector == nil {
+		return true
+	}
+	if selector.CEL != "" {
+		return false
+	}
+	if len(selector.MatchLabels) > 0 {
+		if !labels.SelectorFromSet(selector.MatchLabels).Matches(labels.Set(pod.Labels)) {
+			return false
+		}
+	}
+	// Annotation values routinely violate label-value constraints (length,
+	// character set), so matching them can't reuse label-selector parsing;
+	// compare the requested annotations against the Pod's directly instead.
+	for k, v := range selector.MatchAnnotations {
+		if pod.Annotations[k] != v {
+			return false
+		}
+	}
+	if len(selector.MatchPodPhase) > 0 {
+		phase := string(pod.Status.Phase)
+		matched := false
+		for _, p := range selector.MatchPodPhase {
+			if p == phase {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return false
+		}
+	}
+	return true
+}
+
+// selectStage evaluates a StageSet's Stages in order against a Pod and
+// returns the first one whose selector matches, choosing probabilistically
+// by Weight among consecutive matching Stages that share the same selector.
+// It always starts at Stages[0]; callers driving an ordered, multi-Stage
+// lifecycle for the same Pod across repeated calls should use
+// selectStageFrom instead, so a Pod resumes after its last applied Stage
+// rather than re-matching (and potentially re-rolling) from the top every
+// time.
+func selectStage(stageSet *v1alpha1.StageSet, pod *corev1.Pod) *v1alpha1.StageSpec {
+	stage, _ := selectStageFrom(stageSet, pod, 0)
+	return stage
+}
+
+// selectStageFrom is like selectStage but only considers Stages[from:],
+// returning the chosen Stage together with its index in stageSet.Spec.Stages
+// so the caller can resume at index+1 on the next call.
+func selectStageFrom(stageSet *v1alpha1.StageSet, pod *corev1.Pod, from int) (*v1alpha1.StageSpec, int) {
+	stages := stageSet.Spec.Stages
+	for i := from; i < len(stages); i++ {
+		stage := &stages[i]
+		if !matchStage(stage.Selector, pod) {
+			continue
+		}
+
+		candidates := []*v1alpha1.StageSpec{stage}
+		indices := []int{i}
+		totalWeight := weightOf(stage)
+		for j := i + 1; j < len(stages); j++ {
+			next := &stages[j]
+			if !sameSelector(next.Selector, stage.Selector) || !matchStage(next.Selector, pod) {
+				break
+			}
+			candidates = append(candidates, next)
+			indices = append(indices, j)
+			totalWeight += weightOf(next)
+		}
+		if len(candidates) == 1 {
+			return stage, i
+		}
+		pick := rand.Int31n(totalWeight)
+		for k, c := range candidates {
+			pick -= weightOf(c)
+			if pick < 0 {
+				return c, indices[k]
+			}
+		}
+		return candidates[len(candidates)-1], indices[len(indices)-1]
+	}
+	return nil, -1
+}
+
+func weightOf(stage *v1alpha1.StageSpec) int32 {
+	if stage.Weight <= 0 {
+		return 1
+	}
+	return stage.Weight
+}
+
+func sameSelector(a, b *v1alpha1.StageSelector) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return a.CEL == b.CEL && labels.Set(a.MatchLabels).String() == labels.Set(b.MatchLabels).String() &&
+		labels.Set(a.MatchAnnotations).String() == labels.Set(b.MatchAnnotations).String() &&
+		stringSliceEqual(a.MatchPodPhase, b.MatchPodPhase)
+}
+
+func stringSliceEqual(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// stageDelay samples a concrete delay from a StageDelay, jittering within
+// [DurationFrom, DurationTo] when both are set.
+func stageDelay(d *v1alpha1.StageDelay) time.Duration {
+	if d == nil {
+		return 0
+	}
+	from, to := d.DurationFrom.Duration, d.DurationTo.Duration
+	if from > 0 || to > 0 {
+		if to <= from {
+			return from
+		}
+		return from + time.Duration(rand.Int63n(int64(to-from)))
+	}
+	return d.Duration.Duration
+}
+
+// stageCursor is where a Pod is in an ordered StageSet: the StageSet it
+// entered and the index of the last Stage applied to it from that StageSet.
+type stageCursor struct {
+	stageSet *v1alpha1.StageSet
+	index    int
+}
+
+// StageProvider is a Provider that drives Pod status from CRD-defined
+// Stage/StageSet objects instead of a single PodStatusTemplate. The first
+// time it sees a Pod, it picks the first Stage (across the StageLister's
+// StageSets) whose selector matches. Every later call resumes strictly after
+// that Stage's index in the same StageSet, so a StageSet listing ordered
+// Stages (e.g. Pending -> ContainerCreating -> Running) advances the Pod
+// through them one at a time instead of re-matching, and potentially
+// re-rolling a weighted tie, from the top on every update.
+type StageProvider struct {
+	lister         StageLister
+	funcMap        template.FuncMap
+	nodeConditions []corev1.NodeCondition
+
+	mut      sync.RWMutex
+	statuses map[string]*corev1.PodStatus
+	cursors  map[string]stageCursor
+}
+
+// NewStageProvider returns a Provider that selects Stages from lister.
+func NewStageProvider(lister StageLister, funcMap template.FuncMap, nodeConditions []corev1.NodeCondition) *StageProvider {
+	return &StageProvider{
+		lister:         lister,
+		funcMap:        funcMap,
+		nodeConditions: nodeConditions,
+		statuses:       map[string]*corev1.PodStatus{},
+		cursors:        map[string]stageCursor{},
+	}
+}
+
+// CreatePod selects and applies a Stage for pod.
+func (p *StageProvider) CreatePod(ctx context.Context, pod *corev1.Pod) error {
+	return p.advance(pod)
+}
+
+// UpdatePod re-selects and applies a Stage for pod.
+func (p *StageProvider) UpdatePod(ctx context.Context, pod *corev1.Pod) error {
+	return p.advance(pod)
+}
+
+// DeletePod forgets the cached status and Stage progress for pod.
+func (p *StageProvider) DeletePod(ctx context.Context, pod *corev1.Pod) error {
+	p.mut.Lock()
+	defer p.mut.Unlock()
+	podKey := key(pod.Namespace, pod.Name)
+	delete(p.statuses, podKey)
+	delete(p.cursors, podKey)
+	return nil
+}
+
+// GetPodStatus returns the status produced by the last applied Stage.
+func (p *StageProvider) GetPodStatus(ctx context.Context, namespace, name string) (*corev1.PodStatus, error) {
+	p.mut.RLock()
+	defer p.mut.RUnlock()
+	status, ok := p.statuses[key(namespace, name)]
+	if !ok {
+		return nil, fmt.Errorf("no stage applied yet for pod %s/%s", namespace, name)
+	}
+	return status, nil
+}
+
+// NodeConditions returns the static node conditions this provider was
+// constructed with.
+func (p *StageProvider) NodeConditions(ctx context.Context) []corev1.NodeCondition {
+	return p.nodeConditions
+}
+
+// advance moves pod to its next Stage and applies it: the first matching
+// Stage across the StageLister's StageSets the first time pod is seen,
+// otherwise the first Stage after the one last applied, within the same
+// StageSet pod entered. If pod has already reached a Stage with no matching
+// successor, advance leaves its last reported status untouched rather than
+// re-applying (and re-sleeping the Delay of) the same Stage again.
+func (p *StageProvider) advance(pod *corev1.Pod) error {
+	podKey := key(pod.Namespace, pod.Name)
+
+	p.mut.RLock()
+	cursor, resuming := p.cursors[podKey]
+	p.mut.RUnlock()
+
+	var stageSet *v1alpha1.StageSet
+	var stage *v1alpha1.StageSpec
+	var index int
+	if resuming {
+		stageSet = cursor.stageSet
+		stage, index = selectStageFrom(stageSet, pod, cursor.index+1)
+		if stage == nil {
+			return nil
+		}
+	} else {
+		for _, candidate := range p.lister.List() {
+			if s, i := selectStageFrom(candidate, pod, 0); s != nil {
+				stageSet, stage, index = candidate, s, i
+				break
+			}
+		}
+		if stage == nil {
+			return fmt.Errorf("no stage matches pod %s/%s", pod.Namespace, pod.Name)
+		}
+	}
+
+	if d := stageDelay(stage.Delay); d > 0 {
+		time.Sleep(d)
+	}
+
+	status, err := p.applyNext(stage.Next, pod)
+	if err != nil {
+		return err
+	}
+
+	p.mut.Lock()
+	defer p.mut.Unlock()
+	p.statuses[podKey] = status
+	p.cursors[podKey] = stageCursor{stageSet: stageSet, index: index}
+	return nil
+}
+
+// applyNext renders a Stage's Next into a Pod status, preferring the
+// literal StatusPatch and falling back to rendering StatusTemplate with the
+// same function map as the legacy PodStatusTemplate.
+func (p *StageProvider) applyNext(next v1alpha1.StageNext, pod *corev1.Pod) (*corev1.PodStatus, error) {
+	status := pod.Status.DeepCopy()
+
+	if next.StatusPatch != nil {
+		if next.StatusPatch.Phase != "" {
+			status.Phase = corev1.PodPhase(next.StatusPatch.Phase)
+		}
+		if next.StatusPatch.Reason != "" {
+			status.Reason = next.StatusPatch.Reason
+		}
+		if next.StatusPatch.Message != "" {
+			status.Message = next.StatusPatch.Message
+		}
+		return status, nil
+	}
+
+	if next.StatusTemplate != "" {
+		tpl, err := template.New("stage-next").Funcs(p.funcMap).Parse(next.StatusTemplate)
+		if err != nil {
+			return nil, fmt.Errorf("parse stage status template: %w", err)
+		}
+		var buf bytes.Buffer
+		if err := tpl.Execute(&buf, pod); err != nil {
+			return nil, fmt.Errorf("render stage status template for %s/%s: %w", pod.Namespace, pod.Name, err)
+		}
+		if err := yaml.Unmarshal(buf.Bytes(), status); err != nil {
+			return nil, fmt.Errorf("decode rendered stage status for %s/%s: %w", pod.Namespace, pod.Name, err)
+		}
+		return status, nil
+	}
+
+	return status, nil
+}